@@ -0,0 +1,208 @@
+package sftp
+
+import (
+	"errors"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// ClientFS adapts a Client to the afero.Fs interface, unifying the SFTP and
+// FTP backends behind a single filesystem so callers can swap in
+// afero.MemMapFs (or any other afero.Fs) in tests.
+type ClientFS struct {
+	client *Client
+}
+
+// NewClientFS wraps an existing Client as an afero.Fs.
+func NewClientFS(client *Client) *ClientFS {
+	return &ClientFS{client: client}
+}
+
+var _ afero.Fs = (*ClientFS)(nil)
+
+// Name returns the name of this filesystem.
+func (fs *ClientFS) Name() string {
+	return "ClientFS"
+}
+
+// Create creates a remote file for writing.
+func (fs *ClientFS) Create(name string) (afero.File, error) {
+	f, err := fs.client.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	return newClientFile(name, f), nil
+}
+
+// Mkdir creates the named remote directory.
+func (fs *ClientFS) Mkdir(name string, _ os.FileMode) error {
+	return fs.client.Mkdir(name)
+}
+
+// MkdirAll creates the named remote directory, along with any necessary
+// parents.
+func (fs *ClientFS) MkdirAll(path string, _ os.FileMode) error {
+	return fs.client.MkdirAll(path)
+}
+
+// Open opens the named remote file for reading.
+func (fs *ClientFS) Open(name string) (afero.File, error) {
+	f, err := fs.client.Download(name)
+	if err != nil {
+		return nil, err
+	}
+	return newClientFile(name, f), nil
+}
+
+// OpenFile opens the named remote file according to flag. Only read and
+// write access are distinguished; the remote backends do not support the
+// finer-grained os.OpenFile flags.
+func (fs *ClientFS) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE) != 0 {
+		return fs.Create(name)
+	}
+	return fs.Open(name)
+}
+
+// Remove removes the named remote file.
+func (fs *ClientFS) Remove(name string) error {
+	return fs.client.Remove(name)
+}
+
+// RemoveAll removes path and any children it contains.
+func (fs *ClientFS) RemoveAll(path string) error {
+	return fs.client.RemoveAll(path)
+}
+
+// Rename renames (moves) oldname to newname.
+func (fs *ClientFS) Rename(oldname, newname string) error {
+	return fs.client.Rename(oldname, newname)
+}
+
+// Stat returns the os.FileInfo for the named remote file, following
+// symlinks, per the afero.Fs contract.
+func (fs *ClientFS) Stat(name string) (os.FileInfo, error) {
+	return fs.client.Stat(name)
+}
+
+// Chmod changes the mode of the named remote file. It is only supported on
+// the SFTP backend.
+func (fs *ClientFS) Chmod(name string, mode os.FileMode) error {
+	return fs.client.Chmod(name, mode)
+}
+
+// Chown changes the owner and group of the named remote file. It is only
+// supported on the SFTP backend.
+func (fs *ClientFS) Chown(name string, uid, gid int) error {
+	return fs.client.Chown(name, uid, gid)
+}
+
+// Chtimes changes the access and modification times of the named remote
+// file. It is only supported on the SFTP backend.
+func (fs *ClientFS) Chtimes(name string, atime, mtime time.Time) error {
+	return fs.client.Chtimes(name, atime, mtime)
+}
+
+// clientFile adapts the stream returned by Client.Create/Download to the
+// afero.File interface. Directory listing and some metadata operations are
+// not available on a single transfer stream and return an error.
+type clientFile struct {
+	name string
+	c    io.Closer
+	r    io.Reader
+	w    io.Writer
+}
+
+func newClientFile(name string, stream io.Closer) *clientFile {
+	f := &clientFile{name: name, c: stream}
+	if r, ok := stream.(io.Reader); ok {
+		f.r = r
+	}
+	if w, ok := stream.(io.Writer); ok {
+		f.w = w
+	}
+	return f
+}
+
+func (f *clientFile) Close() error {
+	return f.c.Close()
+}
+
+func (f *clientFile) Read(p []byte) (int, error) {
+	if f.r == nil {
+		return 0, errors.New("sftp: file not open for reading")
+	}
+	return f.r.Read(p)
+}
+
+func (f *clientFile) ReadAt(p []byte, off int64) (int, error) {
+	ra, ok := f.r.(io.ReaderAt)
+	if !ok {
+		return 0, errors.New("sftp: ReadAt not supported")
+	}
+	return ra.ReadAt(p, off)
+}
+
+func (f *clientFile) Seek(offset int64, whence int) (int64, error) {
+	s, ok := f.c.(io.Seeker)
+	if !ok {
+		return 0, errors.New("sftp: Seek not supported")
+	}
+	return s.Seek(offset, whence)
+}
+
+func (f *clientFile) Write(p []byte) (int, error) {
+	if f.w == nil {
+		return 0, errors.New("sftp: file not open for writing")
+	}
+	return f.w.Write(p)
+}
+
+func (f *clientFile) WriteAt(p []byte, off int64) (int, error) {
+	wa, ok := f.w.(io.WriterAt)
+	if !ok {
+		return 0, errors.New("sftp: WriteAt not supported")
+	}
+	return wa.WriteAt(p, off)
+}
+
+func (f *clientFile) WriteString(s string) (int, error) {
+	return f.Write([]byte(s))
+}
+
+func (f *clientFile) Name() string {
+	return f.name
+}
+
+func (f *clientFile) Readdir(int) ([]os.FileInfo, error) {
+	return nil, errors.New("sftp: Readdir not supported, use Client.Walk")
+}
+
+func (f *clientFile) Readdirnames(int) ([]string, error) {
+	return nil, errors.New("sftp: Readdirnames not supported, use Client.Walk")
+}
+
+func (f *clientFile) Stat() (os.FileInfo, error) {
+	s, ok := f.c.(interface{ Stat() (os.FileInfo, error) })
+	if !ok {
+		return nil, errors.New("sftp: Stat not supported on this file")
+	}
+	return s.Stat()
+}
+
+func (f *clientFile) Sync() error {
+	return nil
+}
+
+func (f *clientFile) Truncate(size int64) error {
+	t, ok := f.c.(interface{ Truncate(int64) error })
+	if !ok {
+		return errors.New("sftp: Truncate not supported on this file")
+	}
+	return t.Truncate(size)
+}
+
+var _ afero.File = (*clientFile)(nil)
@@ -0,0 +1,370 @@
+package sftp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// SymlinkPolicy controls how UploadDir and DownloadDir treat symlinks in
+// the source tree.
+type SymlinkPolicy int
+
+const (
+	// SymlinkFollow copies the file or directory a symlink points to, as
+	// if it were not a symlink.
+	SymlinkFollow SymlinkPolicy = iota
+	// SymlinkCopy recreates the symlink itself at the destination. Only
+	// supported on the SFTP backend.
+	SymlinkCopy
+	// SymlinkSkip ignores symlinks entirely.
+	SymlinkSkip
+)
+
+// DirOptions configures UploadDir and DownloadDir.
+type DirOptions struct {
+	Symlink     SymlinkPolicy
+	Concurrency int
+	ShouldCopy  func(src, dst os.FileInfo) bool
+	OnProgress  func(path string, bytes, total int64)
+}
+
+// DirOpt configures a DirOptions. See WithSymlinkPolicy, WithConcurrency,
+// WithShouldCopy, and WithProgress.
+type DirOpt func(*DirOptions)
+
+// WithSymlinkPolicy sets how symlinks in the source tree are handled.
+// Defaults to SymlinkFollow.
+func WithSymlinkPolicy(policy SymlinkPolicy) DirOpt {
+	return func(o *DirOptions) { o.Symlink = policy }
+}
+
+// WithConcurrency sets the number of files transferred in parallel.
+// Defaults to 4.
+func WithConcurrency(n int) DirOpt {
+	return func(o *DirOptions) { o.Concurrency = n }
+}
+
+// WithShouldCopy sets a predicate consulted before transferring each file;
+// returning false skips it, enabling incremental sync (e.g. skip when
+// size and mtime already match). It is only consulted when the destination
+// already exists; a file whose destination does not exist yet is always
+// copied.
+func WithShouldCopy(fn func(src, dst os.FileInfo) bool) DirOpt {
+	return func(o *DirOptions) { o.ShouldCopy = fn }
+}
+
+// WithProgress sets a callback invoked as each file is transferred.
+func WithProgress(fn func(path string, bytes, total int64)) DirOpt {
+	return func(o *DirOptions) { o.OnProgress = fn }
+}
+
+func newDirOptions(opts []DirOpt) DirOptions {
+	options := DirOptions{Concurrency: 4}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.Concurrency < 1 {
+		options.Concurrency = 1
+	}
+	return options
+}
+
+var warnFTPMetadataOnce sync.Once
+
+func warnFTPMetadata() {
+	warnFTPMetadataOnce.Do(func() {
+		fmt.Fprintln(os.Stderr, "sftp: file mode and modification time are not propagated over FTP")
+	})
+}
+
+// UploadDir recursively uploads localDir to remoteDir, creating remote
+// directories as needed and transferring files through UploadFile. Note that
+// under SymlinkFollow, a symlink to a directory is itself created remotely
+// but its contents are not recursed into, since filepath.Walk (used to
+// traverse localDir) does not follow symlinks.
+func (c *Client) UploadDir(localDir, remoteDir string, opts ...DirOpt) error {
+	options := newDirOptions(opts)
+
+	if err := c.MkdirAll(remoteDir); err != nil {
+		return fmt.Errorf("mkdir %s: %w", remoteDir, err)
+	}
+
+	sem := make(chan struct{}, options.Concurrency)
+	var wg sync.WaitGroup
+	var errOnce sync.Once
+	var firstErr error
+	setErr := func(err error) {
+		errOnce.Do(func() { firstErr = err })
+	}
+
+	walkErr := filepath.Walk(localDir, func(localPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(localDir, localPath)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		remotePath := filepath.Join(remoteDir, rel)
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			switch options.Symlink {
+			case SymlinkSkip:
+				return nil
+			case SymlinkCopy:
+				return c.uploadSymlink(localPath, remotePath)
+			default: // SymlinkFollow
+				target, err := filepath.EvalSymlinks(localPath)
+				if err != nil {
+					return err
+				}
+				localPath = target
+				info, err = os.Stat(target)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		if info.IsDir() {
+			return c.MkdirAll(remotePath)
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(localPath, remotePath string, info os.FileInfo) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := c.uploadOne(localPath, remotePath, info, options); err != nil {
+				setErr(fmt.Errorf("upload %s: %w", remotePath, err))
+			}
+		}(localPath, remotePath, info)
+		return nil
+	})
+
+	wg.Wait()
+	if walkErr != nil {
+		return walkErr
+	}
+	return firstErr
+}
+
+func (c *Client) uploadOne(localPath, remotePath string, info os.FileInfo, options DirOptions) error {
+	if options.ShouldCopy != nil {
+		dstInfo, err := c.Info(remotePath)
+		if err == nil && !options.ShouldCopy(info, dstInfo) {
+			return nil
+		}
+	}
+
+	f, err := os.Open(localPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var source io.Reader = f
+	if options.OnProgress != nil {
+		source = &progressReader{r: f, path: remotePath, total: info.Size(), onProgress: options.OnProgress}
+	}
+
+	if err := c.UploadFile(remotePath, source); err != nil {
+		return err
+	}
+
+	if _, ftpClient := c.conns(); ftpClient != nil {
+		warnFTPMetadata()
+		return nil
+	}
+	if err := c.Chmod(remotePath, info.Mode()); err != nil {
+		return err
+	}
+	return c.Chtimes(remotePath, info.ModTime(), info.ModTime())
+}
+
+func (c *Client) uploadSymlink(localPath, remotePath string) error {
+	if err := c.connect(); err != nil {
+		return fmt.Errorf("connect: %w", err)
+	}
+	sftpClient, ftpClient := c.conns()
+	if ftpClient != nil {
+		return errors.New("Symlink not implemented for FTP")
+	}
+	target, err := os.Readlink(localPath)
+	if err != nil {
+		return err
+	}
+	return sftpClient.Symlink(target, remotePath)
+}
+
+// DownloadDir recursively downloads remoteDir to localDir, creating local
+// directories as needed and transferring files through Download. Note that
+// under SymlinkFollow, a symlink to a directory is itself created locally
+// but its contents are not recursed into, since the underlying SFTP walk
+// does not follow symlinks.
+func (c *Client) DownloadDir(remoteDir, localDir string, opts ...DirOpt) error {
+	options := newDirOptions(opts)
+
+	if err := os.MkdirAll(localDir, 0o755); err != nil {
+		return err
+	}
+
+	sem := make(chan struct{}, options.Concurrency)
+	var wg sync.WaitGroup
+	var errOnce sync.Once
+	var firstErr error
+	setErr := func(err error) {
+		errOnce.Do(func() { firstErr = err })
+	}
+
+	walkErr := c.Walk(remoteDir, func(remotePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(remoteDir, remotePath)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		localPath := filepath.Join(localDir, rel)
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			switch options.Symlink {
+			case SymlinkSkip:
+				return nil
+			case SymlinkCopy:
+				return c.downloadSymlink(remotePath, localPath)
+			default: // SymlinkFollow
+				followed, err := c.Stat(remotePath)
+				if err != nil {
+					return err
+				}
+				info = followed
+			}
+		}
+
+		if info.IsDir() {
+			return os.MkdirAll(localPath, 0o755)
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(remotePath, localPath string, info os.FileInfo) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := c.downloadOne(remotePath, localPath, info, options); err != nil {
+				setErr(fmt.Errorf("download %s: %w", remotePath, err))
+			}
+		}(remotePath, localPath, info)
+		return nil
+	})
+
+	wg.Wait()
+	if walkErr != nil {
+		return walkErr
+	}
+	return firstErr
+}
+
+func (c *Client) downloadOne(remotePath, localPath string, info os.FileInfo, options DirOptions) error {
+	if options.ShouldCopy != nil {
+		if dstInfo, err := os.Stat(localPath); err == nil && !options.ShouldCopy(info, dstInfo) {
+			return nil
+		}
+	}
+
+	src, err := c.Download(remotePath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	if err := os.MkdirAll(filepath.Dir(localPath), 0o755); err != nil {
+		return err
+	}
+
+	dst, err := os.Create(localPath)
+	if err != nil {
+		return err
+	}
+
+	var destination io.Writer = dst
+	if options.OnProgress != nil {
+		destination = &progressWriter{w: dst, path: remotePath, total: info.Size(), onProgress: options.OnProgress}
+	}
+
+	if _, err := io.Copy(destination, src); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Chmod(localPath, info.Mode()); err != nil {
+		return err
+	}
+	return os.Chtimes(localPath, info.ModTime(), info.ModTime())
+}
+
+func (c *Client) downloadSymlink(remotePath, localPath string) error {
+	if err := c.connect(); err != nil {
+		return fmt.Errorf("connect: %w", err)
+	}
+	sftpClient, ftpClient := c.conns()
+	if ftpClient != nil {
+		return errors.New("Symlink not implemented for FTP")
+	}
+	target, err := sftpClient.ReadLink(remotePath)
+	if err != nil {
+		return err
+	}
+	return os.Symlink(target, localPath)
+}
+
+// progressReader wraps an io.Reader, reporting cumulative bytes read to
+// onProgress as the upload consumes it.
+type progressReader struct {
+	r          io.Reader
+	path       string
+	total      int64
+	read       int64
+	onProgress func(path string, bytes, total int64)
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.read += int64(n)
+		p.onProgress(p.path, p.read, p.total)
+	}
+	return n, err
+}
+
+// progressWriter wraps an io.Writer, reporting cumulative bytes written to
+// onProgress as the download fills it.
+type progressWriter struct {
+	w          io.Writer
+	path       string
+	total      int64
+	written    int64
+	onProgress func(path string, bytes, total int64)
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	if n > 0 {
+		p.written += int64(n)
+		p.onProgress(p.path, p.written, p.total)
+	}
+	return n, err
+}
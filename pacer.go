@@ -0,0 +1,127 @@
+package sftp
+
+import (
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RetryConfig controls how Client retries a failed network call.
+type RetryConfig struct {
+	// MaxAttempts is the maximum number of times a call is attempted,
+	// including the first. Defaults to 1 (no retries) when <= 0.
+	MaxAttempts int
+	// MinSleep is the sleep before the first retry. Defaults to 10ms.
+	MinSleep time.Duration
+	// MaxSleep caps the sleep between retries. Defaults to 2s.
+	MaxSleep time.Duration
+	// DecayConstant controls how fast the sleep grows between retries;
+	// the sleep is multiplied by DecayConstant after each failure, up to
+	// MaxSleep. Defaults to 2.
+	DecayConstant int
+	// Retryable reports whether err is worth retrying. Defaults to
+	// IsRetryableError.
+	Retryable func(error) bool
+}
+
+// pacer paces retried calls, sleeping an exponentially-decaying duration
+// between attempts and doubling (by DecayConstant) on failure up to
+// MaxSleep, analogous to rclone's lib/pacer.
+type pacer struct {
+	config RetryConfig
+
+	mu    sync.Mutex
+	sleep time.Duration
+}
+
+// newPacer builds a pacer from config, filling in defaults for anything
+// left at its zero value.
+func newPacer(config RetryConfig) *pacer {
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 1
+	}
+	if config.MinSleep <= 0 {
+		config.MinSleep = 10 * time.Millisecond
+	}
+	if config.MaxSleep <= 0 {
+		config.MaxSleep = 2 * time.Second
+	}
+	if config.DecayConstant <= 0 {
+		config.DecayConstant = 2
+	}
+	if config.Retryable == nil {
+		config.Retryable = IsRetryableError
+	}
+	return &pacer{config: config, sleep: config.MinSleep}
+}
+
+// call runs fn, at most MaxAttempts times in total, retrying it while it
+// returns a retryable error. reconnect, if non-nil, is invoked before each retry so
+// the next attempt can re-establish the connection.
+func (p *pacer) call(fn func() error, reconnect func()) error {
+	var err error
+	for attempt := 0; attempt < p.config.MaxAttempts; attempt++ {
+		err = fn()
+		if err == nil {
+			p.reduceSleep()
+			return nil
+		}
+		if !p.config.Retryable(err) || attempt == p.config.MaxAttempts-1 {
+			return err
+		}
+		if reconnect != nil {
+			reconnect()
+		}
+		time.Sleep(p.nextSleep())
+	}
+	return err
+}
+
+// nextSleep returns the sleep to use before the next attempt and grows it
+// for the attempt after that.
+func (p *pacer) nextSleep() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	sleep := p.sleep
+	p.sleep *= time.Duration(p.config.DecayConstant)
+	if p.sleep > p.config.MaxSleep {
+		p.sleep = p.config.MaxSleep
+	}
+	return sleep
+}
+
+// reduceSleep resets the sleep back to MinSleep after a successful call.
+func (p *pacer) reduceSleep() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sleep = p.config.MinSleep
+}
+
+// IsRetryableError reports whether err is transient and worth retrying:
+// EOF/unexpected EOF, connection resets, temporary *net.OpError, or a
+// goftp 4xx temporary FTP response code.
+func IsRetryableError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Temporary() {
+		return true
+	}
+	msg := err.Error()
+	if strings.Contains(msg, "connection reset") {
+		return true
+	}
+	for _, code := range []string{"421", "425", "426", "450", "451", "452"} {
+		if strings.HasPrefix(msg, code+" ") || strings.HasPrefix(msg, code+"-") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,118 @@
+package sftp
+
+import (
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestPacerCallRetriesRetryableErrors(t *testing.T) {
+	p := newPacer(RetryConfig{
+		MaxAttempts: 3,
+		MinSleep:    time.Millisecond,
+	})
+
+	attempts := 0
+	reconnects := 0
+	err := p.call(func() error {
+		attempts++
+		if attempts < 3 {
+			return io.ErrUnexpectedEOF
+		}
+		return nil
+	}, func() { reconnects++ })
+
+	if err != nil {
+		t.Fatalf("call returned %v, want nil", err)
+	}
+	if attempts != 3 {
+		t.Fatalf("attempts = %d, want 3", attempts)
+	}
+	if reconnects != 2 {
+		t.Fatalf("reconnects = %d, want 2 (one before each retry)", reconnects)
+	}
+}
+
+func TestPacerCallStopsOnNonRetryableError(t *testing.T) {
+	p := newPacer(RetryConfig{MaxAttempts: 3, MinSleep: time.Millisecond})
+
+	attempts := 0
+	wantErr := errors.New("permission denied")
+	err := p.call(func() error {
+		attempts++
+		return wantErr
+	}, nil)
+
+	if err != wantErr {
+		t.Fatalf("call returned %v, want %v", err, wantErr)
+	}
+	if attempts != 1 {
+		t.Fatalf("attempts = %d, want 1 (non-retryable errors must not retry)", attempts)
+	}
+}
+
+func TestPacerCallStopsAtMaxAttempts(t *testing.T) {
+	p := newPacer(RetryConfig{MaxAttempts: 2, MinSleep: time.Millisecond})
+
+	attempts := 0
+	err := p.call(func() error {
+		attempts++
+		return io.ErrUnexpectedEOF
+	}, nil)
+
+	if err != io.ErrUnexpectedEOF {
+		t.Fatalf("call returned %v, want io.ErrUnexpectedEOF", err)
+	}
+	if attempts != 2 {
+		t.Fatalf("attempts = %d, want 2 (MaxAttempts)", attempts)
+	}
+}
+
+func TestPacerSleepGrowsAndResets(t *testing.T) {
+	p := newPacer(RetryConfig{
+		MinSleep:      10 * time.Millisecond,
+		MaxSleep:      30 * time.Millisecond,
+		DecayConstant: 2,
+	})
+
+	if got := p.nextSleep(); got != 10*time.Millisecond {
+		t.Fatalf("first nextSleep = %v, want 10ms", got)
+	}
+	if got := p.nextSleep(); got != 20*time.Millisecond {
+		t.Fatalf("second nextSleep = %v, want 20ms", got)
+	}
+	if got := p.nextSleep(); got != 30*time.Millisecond {
+		t.Fatalf("third nextSleep = %v, want 30ms (capped at MaxSleep)", got)
+	}
+
+	p.reduceSleep()
+	if got := p.nextSleep(); got != 10*time.Millisecond {
+		t.Fatalf("nextSleep after reduceSleep = %v, want 10ms", got)
+	}
+}
+
+func TestIsRetryableError(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"EOF", io.EOF, true},
+		{"unexpected EOF", io.ErrUnexpectedEOF, true},
+		{"connection reset", errors.New("read: connection reset by peer"), true},
+		{"ftp 421 temporary", errors.New("421 Service not available"), true},
+		{"ftp 550 permanent", errors.New("550 File not found"), false},
+		{"other", errors.New("permission denied"), false},
+		{"temporary net error", &net.DNSError{IsTemporary: true}, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := IsRetryableError(tc.err); got != tc.want {
+				t.Fatalf("IsRetryableError(%v) = %v, want %v", tc.err, got, tc.want)
+			}
+		})
+	}
+}
@@ -2,8 +2,8 @@
 package sftp
 
 import (
-	"bytes"
 	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
 	"io"
@@ -11,11 +11,15 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/pkg/sftp"
 	"github.com/secsy/goftp"
 	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
 )
 
 // Config represents SSH connection parameters.
@@ -29,106 +33,245 @@ type Config struct {
 	Timeout          time.Duration
 	ActiveTransfers  bool
 	ActiveListenAddr string
+
+	// KnownHostsFile, if set, verifies the server's SSH host key against
+	// entries in an OpenSSH known_hosts file.
+	KnownHostsFile string
+	// HostKeyCallback, if set, is used as-is to verify the server's SSH
+	// host key. It takes precedence over KnownHostsFile and
+	// HostKeyFingerprints.
+	HostKeyCallback ssh.HostKeyCallback
+	// HostKeyFingerprints, if set, verifies the server's SSH host key
+	// against a list of allowed SHA256 fingerprints, each formatted as
+	// returned by ssh.FingerprintSHA256 (e.g. "SHA256:base64...").
+	HostKeyFingerprints []string
+	// InsecureSkipHostKeyVerify disables SSH host key verification and TLS
+	// certificate verification. It must be explicitly set to true; by
+	// default unknown hosts are rejected. It does not disable SSH host key
+	// verification when HostKeyCallback, KnownHostsFile or
+	// HostKeyFingerprints is also set.
+	InsecureSkipHostKeyVerify bool
+
+	// TLSRootCAs, if set, is used to verify the FTPS server's certificate
+	// instead of the system root CAs.
+	TLSRootCAs *x509.CertPool
+	// TLSServerName overrides the server name used for TLS certificate
+	// verification. Defaults to Server.
+	TLSServerName string
+
+	// UseAgent authenticates via the running SSH agent referenced by
+	// SSH_AUTH_SOCK, in addition to any configured keys/password.
+	UseAgent bool
+	// PrivateKeys lists additional PEM-encoded private keys to offer,
+	// alongside PrivateKey.
+	PrivateKeys []string
+	// PrivateKeyPassphrase decrypts PrivateKey and PrivateKeys when they
+	// are encrypted.
+	PrivateKeyPassphrase string
+
+	// Retry configures the retry/pacer behaviour applied to network calls.
+	// Upload is never retried, UploadFile is retried only for a seekable
+	// source, and Walk and DownloadRange retry only the initial connection.
+	// The zero value applies sensible defaults; see RetryConfig.
+	Retry RetryConfig
 }
 
 // Client provides basic functionality to interact with a SFTP server.
 type Client struct {
-	config     Config
+	config Config
+	pacer  *pacer
+
+	// mu guards sshClient/sftpClient/ftpClient, which connect() and reset()
+	// mutate and UploadDir/DownloadDir/DownloadConcurrent read concurrently
+	// from multiple goroutines against the same Client.
+	mu         sync.Mutex
 	sshClient  *ssh.Client
 	sftpClient *sftp.Client
 	ftpClient  *goftp.Client
 }
 
+// conns returns the currently active sftp/ftp client pointers under mu, so
+// callers get a stable snapshot to operate on instead of re-reading the
+// fields (which a concurrent reset/connect may be mutating).
+func (c *Client) conns() (*sftp.Client, *goftp.Client) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.sftpClient, c.ftpClient
+}
+
 // New initialises SSH and SFTP clients and returns Client type to use.
 func New(config Config) (*Client, error) {
 	c := &Client{
 		config: config,
+		pacer:  newPacer(config.Retry),
 	}
 
-	if err := c.connect(); err != nil {
+	if err := c.withRetry(c.connect); err != nil {
 		return nil, err
 	}
 
 	return c, nil
 }
 
-// Create creates a remote/destination file for I/O.
-func (c *Client) Create(filePath string) (io.ReadWriteCloser, error) {
-	if err := c.connect(); err != nil {
-		return nil, fmt.Errorf("connect: %w", err)
+// withRetry runs fn, retrying it through c.pacer while it returns a
+// retryable error, dropping sshClient/sftpClient/ftpClient before each
+// retry so the next attempt reconnects from scratch.
+func (c *Client) withRetry(fn func() error) error {
+	return c.pacer.call(fn, c.reset)
+}
+
+// reset closes the current connections, if any, and drops them so the
+// next connect() call dials a fresh one.
+func (c *Client) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.sftpClient != nil {
+		c.sftpClient.Close()
+	}
+	if c.sshClient != nil {
+		c.sshClient.Close()
 	}
 	if c.ftpClient != nil {
-		return nil, errors.New("Create not implemented")
+		c.ftpClient.Close()
 	}
-	return c.sftpClient.Create(filePath)
+	c.sshClient = nil
+	c.sftpClient = nil
+	c.ftpClient = nil
+}
+
+// Create creates a remote/destination file for I/O.
+func (c *Client) Create(filePath string) (io.ReadWriteCloser, error) {
+	var destination io.ReadWriteCloser
+	err := c.withRetry(func() error {
+		if err := c.connect(); err != nil {
+			return fmt.Errorf("connect: %w", err)
+		}
+		sftpClient, ftpClient := c.conns()
+		if ftpClient != nil {
+			return errors.New("Create not implemented")
+		}
+		f, err := sftpClient.Create(filePath)
+		if err != nil {
+			return err
+		}
+		destination = f
+		return nil
+	})
+	return destination, err
 }
 
 // Remove a file or directory
 func (c *Client) Remove(path string) error {
-	if err := c.connect(); err != nil {
-		return fmt.Errorf("connect: %w", err)
-	}
-	if c.ftpClient != nil {
-		return c.ftpClient.Delete(path)
-	}
-	return c.sftpClient.Remove(path)
+	return c.withRetry(func() error {
+		if err := c.connect(); err != nil {
+			return fmt.Errorf("connect: %w", err)
+		}
+		sftpClient, ftpClient := c.conns()
+		if ftpClient != nil {
+			return ftpClient.Delete(path)
+		}
+		return sftpClient.Remove(path)
+	})
 }
 
 // Glob returns the names of all files matching pattern or nil if there is no matching file. The syntax of patterns is the same as in Match. The pattern may describe hierarchical names such as /usr/*/bin/ed.
 // Glob ignores file system errors such as I/O errors reading directories. The only possible returned error is ErrBadPattern, when pattern is malformed.
 func (c *Client) Glob(pattern string) (matches []string, err error) {
-	if err := c.connect(); err != nil {
-		return nil, fmt.Errorf("connect: %w", err)
-	}
-	if c.ftpClient != nil {
-		files, err := c.ftpClient.ReadDir(filepath.Dir(pattern))
-		if err != nil {
-			return nil, err
+	err = c.withRetry(func() error {
+		if err := c.connect(); err != nil {
+			return fmt.Errorf("connect: %w", err)
 		}
-		var matches = []string{}
-		for _, remoteFile := range files {
-			match, err := filepath.Match(pattern, filepath.Dir(pattern)+"/"+remoteFile.Name())
+		sftpClient, ftpClient := c.conns()
+		if ftpClient != nil {
+			files, err := ftpClient.ReadDir(filepath.Dir(pattern))
 			if err != nil {
-				return nil, err
+				return err
 			}
-			if match {
-				matches = append(matches, filepath.Dir(pattern)+"/"+remoteFile.Name())
+			matches = []string{}
+			for _, remoteFile := range files {
+				match, err := filepath.Match(pattern, filepath.Dir(pattern)+"/"+remoteFile.Name())
+				if err != nil {
+					return err
+				}
+				if match {
+					matches = append(matches, filepath.Dir(pattern)+"/"+remoteFile.Name())
+				}
 			}
+			return nil
 		}
-		return matches, nil
-	}
-	return c.sftpClient.Glob(pattern)
+		matches, err = sftpClient.Glob(pattern)
+		return err
+	})
+	return matches, err
 }
 
 func (c *Client) UploadFile(path string, source io.Reader) error {
-
-	if err := c.connect(); err != nil {
-		return fmt.Errorf("connect: %w", err)
-	}
-
-	if c.ftpClient != nil {
-		return c.ftpClient.Store(path, source)
-	}
-	// Write back the config file
-	destination, err := c.Create(path)
-	if err != nil {
-		return err
+	seeker, seekable := source.(io.Seeker)
+	upload := func() error {
+		if err := c.connect(); err != nil {
+			return fmt.Errorf("connect: %w", err)
+		}
+		if seekable {
+			if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+		}
+		sftpClient, ftpClient := c.conns()
+		if ftpClient != nil {
+			return ftpClient.Store(path, source)
+		}
+		// Write back the config file
+		destination, err := createRaw(ftpClient, sftpClient, path)
+		if err != nil {
+			return err
+		}
+		defer destination.Close()
+		// Upload the remoteconfig file to a remote location as in 1MB (byte) chunks.
+		return uploadRaw(ftpClient, source, destination, 1000000)
 	}
-	defer destination.Close()
-	// Upload the remoteconfig file to a remote location as in 1MB (byte) chunks.
-	if err := c.Upload(source, destination, 1000000); err != nil {
-		return err
+	// A retry reads source again; unless source can be seeked back to the
+	// start, that would silently resume mid-stream and corrupt the upload.
+	if !seekable {
+		return upload()
 	}
-	return nil
+	return c.withRetry(upload)
 }
 
 // Upload writes local/source file data streams to remote/destination file.
+// Unlike UploadFile, destination is supplied by the caller rather than
+// created fresh per attempt, so a failed write may already have partially
+// filled it; Upload therefore never retries, regardless of whether source
+// can be seeked back to the start.
 func (c *Client) Upload(source io.Reader, destination io.Writer, size int) error {
 	if err := c.connect(); err != nil {
 		return fmt.Errorf("connect: %w", err)
 	}
+	if seeker, ok := source.(io.Seeker); ok {
+		if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+	}
+	_, ftpClient := c.conns()
+	return uploadRaw(ftpClient, source, destination, size)
+}
 
-	if c.ftpClient != nil {
+// createRaw creates a remote file without going through the retry pacer;
+// callers that already hold a retry loop (e.g. UploadFile) use this so the
+// Create isn't paced twice. sftpClient/ftpClient are a snapshot taken by
+// the caller so this doesn't need to re-read the Client's connection
+// fields.
+func createRaw(ftpClient *goftp.Client, sftpClient *sftp.Client, filePath string) (io.ReadWriteCloser, error) {
+	if ftpClient != nil {
+		return nil, errors.New("Create not implemented")
+	}
+	return sftpClient.Create(filePath)
+}
+
+// uploadRaw writes source to destination in size-byte chunks, without
+// going through the retry pacer. ftpClient is a snapshot taken by the
+// caller; see createRaw.
+func uploadRaw(ftpClient *goftp.Client, source io.Reader, destination io.Writer, size int) error {
+	if ftpClient != nil {
 		return errors.New("Upload with writer not implemented")
 	}
 
@@ -166,54 +309,328 @@ func (c *Client) Upload(source io.Reader, destination io.Writer, size int) error
 
 // Download returns remote/destination file for reading.
 func (c *Client) Download(filePath string) (io.ReadCloser, error) {
-	if err := c.connect(); err != nil {
-		return nil, fmt.Errorf("connect: %w", err)
-	}
+	var source io.ReadCloser
+	err := c.withRetry(func() error {
+		if err := c.connect(); err != nil {
+			return fmt.Errorf("connect: %w", err)
+		}
 
-	if c.ftpClient != nil {
-		const fn = ".sftp.tmp"
-		tmp, err := os.Create(fn)
+		sftpClient, ftpClient := c.conns()
+		if ftpClient != nil {
+			pr, pw := io.Pipe()
+			go func() {
+				err := ftpClient.Retrieve(filePath, pw)
+				if err != nil && strings.Contains(err.Error(), "550-Failed to open file") {
+					err = os.ErrNotExist
+				}
+				pw.CloseWithError(err)
+			}()
+			source = pr
+			return nil
+		}
+
+		f, err := sftpClient.Open(filePath)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		defer tmp.Close()
-		err = c.ftpClient.Retrieve(filePath, tmp)
+		source = f
+		return nil
+	})
+	return source, err
+}
+
+// Info gets the details of a file. If the file was not found, an error is returned.
+func (c *Client) Info(filePath string) (os.FileInfo, error) {
+	var info os.FileInfo
+	err := c.withRetry(func() error {
+		if err := c.connect(); err != nil {
+			return fmt.Errorf("connect: %w", err)
+		}
+
+		sftpClient, ftpClient := c.conns()
+		if ftpClient != nil {
+			var err error
+			info, err = ftpClient.Stat(filePath)
+			return err
+		}
+
+		var err error
+		info, err = sftpClient.Lstat(filePath)
 		if err != nil {
-			if strings.Contains(err.Error(), "550-Failed to open file") {
-				err = os.ErrNotExist
-			}
-			return nil, err
+			return fmt.Errorf("file stats: %w", err)
+		}
+		return nil
+	})
+	return info, err
+}
+
+// Stat gets the details of a file, following symlinks (on the FTP backend,
+// Info already does this, since FTP has no lstat equivalent). Use this
+// instead of Info when a symlink's target, rather than the link itself,
+// is what's wanted.
+func (c *Client) Stat(filePath string) (os.FileInfo, error) {
+	var info os.FileInfo
+	err := c.withRetry(func() error {
+		if err := c.connect(); err != nil {
+			return fmt.Errorf("connect: %w", err)
+		}
+
+		sftpClient, ftpClient := c.conns()
+		if ftpClient != nil {
+			var err error
+			info, err = ftpClient.Stat(filePath)
+			return err
+		}
+
+		var err error
+		info, err = sftpClient.Stat(filePath)
+		if err != nil {
+			return fmt.Errorf("file stats: %w", err)
+		}
+		return nil
+	})
+	return info, err
+}
+
+// Rename renames (moves) oldpath to newpath.
+func (c *Client) Rename(oldpath, newpath string) error {
+	return c.withRetry(func() error {
+		if err := c.connect(); err != nil {
+			return fmt.Errorf("connect: %w", err)
+		}
+		sftpClient, ftpClient := c.conns()
+		if ftpClient != nil {
+			return ftpClient.Rename(oldpath, newpath)
+		}
+		return sftpClient.Rename(oldpath, newpath)
+	})
+}
+
+// Mkdir creates the named remote directory.
+func (c *Client) Mkdir(path string) error {
+	return c.withRetry(func() error {
+		if err := c.connect(); err != nil {
+			return fmt.Errorf("connect: %w", err)
+		}
+		sftpClient, ftpClient := c.conns()
+		if ftpClient != nil {
+			_, err := ftpClient.Mkdir(path)
+			return err
+		}
+		return sftpClient.Mkdir(path)
+	})
+}
+
+// MkdirAll creates the named remote directory, along with any necessary
+// parents, similar to os.MkdirAll. It does nothing if path is already a
+// directory, and returns an error if path exists but is not one.
+func (c *Client) MkdirAll(path string) error {
+	return c.withRetry(func() error {
+		if err := c.connect(); err != nil {
+			return fmt.Errorf("connect: %w", err)
 		}
-		//Close the temp file before removing it
-		tmp.Close()
-		buf, err := os.ReadFile(fn)
-		os.Remove(fn)
-		return io.NopCloser(bytes.NewBuffer(buf)), err
+		sftpClient, ftpClient := c.conns()
+		if ftpClient != nil {
+			return mkdirAllFTP(ftpClient, path)
+		}
+		return sftpClient.MkdirAll(path)
+	})
+}
+
+func mkdirAllFTP(ftpClient *goftp.Client, path string) error {
+	path = filepath.Clean(path)
+	if path == "." || path == "/" {
+		return nil
+	}
+	if info, err := ftpClient.Stat(path); err == nil {
+		if !info.IsDir() {
+			return &os.PathError{Op: "mkdir", Path: path, Err: syscall.ENOTDIR}
+		}
+		return nil
+	}
+	if err := mkdirAllFTP(ftpClient, filepath.Dir(path)); err != nil {
+		return err
 	}
+	_, err := ftpClient.Mkdir(path)
+	return err
+}
 
-	return c.sftpClient.Open(filePath)
+// RemoveAll removes path and any children it contains, similar to
+// os.RemoveAll.
+func (c *Client) RemoveAll(path string) error {
+	return c.withRetry(func() error {
+		if err := c.connect(); err != nil {
+			return fmt.Errorf("connect: %w", err)
+		}
+		sftpClient, ftpClient := c.conns()
+		if ftpClient != nil {
+			return removeAllFTP(ftpClient, path)
+		}
+		return sftpClient.RemoveAll(path)
+	})
 }
 
-// Info gets the details of a file. If the file was not found, an error is returned.
-func (c *Client) Info(filePath string) (os.FileInfo, error) {
-	if err := c.connect(); err != nil {
-		return nil, fmt.Errorf("connect: %w", err)
+func removeAllFTP(ftpClient *goftp.Client, path string) error {
+	info, err := ftpClient.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return ftpClient.Delete(path)
+	}
+	entries, err := ftpClient.ReadDir(path)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := removeAllFTP(ftpClient, filepath.Join(path, entry.Name())); err != nil {
+			return err
+		}
 	}
+	return ftpClient.Rmdir(path)
+}
 
-	if c.ftpClient != nil {
-		return c.ftpClient.Stat(filePath)
+// Chmod changes the mode of the named remote file. It is only supported on
+// the SFTP backend.
+func (c *Client) Chmod(path string, mode os.FileMode) error {
+	return c.withRetry(func() error {
+		if err := c.connect(); err != nil {
+			return fmt.Errorf("connect: %w", err)
+		}
+		sftpClient, ftpClient := c.conns()
+		if ftpClient != nil {
+			return errors.New("Chmod not implemented for FTP")
+		}
+		return sftpClient.Chmod(path, mode)
+	})
+}
+
+// Chtimes changes the access and modification times of the named remote
+// file. It is only supported on the SFTP backend.
+func (c *Client) Chtimes(path string, atime, mtime time.Time) error {
+	return c.withRetry(func() error {
+		if err := c.connect(); err != nil {
+			return fmt.Errorf("connect: %w", err)
+		}
+		sftpClient, ftpClient := c.conns()
+		if ftpClient != nil {
+			return errors.New("Chtimes not implemented for FTP")
+		}
+		return sftpClient.Chtimes(path, atime, mtime)
+	})
+}
+
+// Chown changes the owner and group of the named remote file. It is only
+// supported on the SFTP backend.
+func (c *Client) Chown(path string, uid, gid int) error {
+	return c.withRetry(func() error {
+		if err := c.connect(); err != nil {
+			return fmt.Errorf("connect: %w", err)
+		}
+		sftpClient, ftpClient := c.conns()
+		if ftpClient != nil {
+			return errors.New("Chown not implemented for FTP")
+		}
+		return sftpClient.Chown(path, uid, gid)
+	})
+}
+
+// Walk walks the remote file tree rooted at root, calling fn for each file
+// or directory in the tree, including root. It mirrors filepath.Walk.
+//
+// Only the initial connection is retried through the pacer; once walking
+// has started, a transient failure partway through would otherwise replay
+// fn for paths already visited, so it is surfaced to the caller as-is.
+func (c *Client) Walk(root string, fn filepath.WalkFunc) error {
+	if err := c.withRetry(c.connect); err != nil {
+		return fmt.Errorf("connect: %w", err)
 	}
+	sftpClient, ftpClient := c.conns()
+	if ftpClient != nil {
+		if err := walkFTP(ftpClient, root, fn); err != nil && err != errSkipSiblings {
+			return err
+		}
+		return nil
+	}
+	walker := sftpClient.Walk(root)
+	// skipSiblingsOf holds the parent directory of a file that returned
+	// SkipDir, until the walk moves past the remaining entries of that
+	// directory, matching filepath.Walk's contract that SkipDir on a
+	// non-directory skips the rest of its containing directory.
+	var skipSiblingsOf string
+	for walker.Step() {
+		path := walker.Path()
+		if skipSiblingsOf != "" {
+			if filepath.Dir(path) == skipSiblingsOf {
+				walker.SkipDir()
+				continue
+			}
+			skipSiblingsOf = ""
+		}
+		if err := walker.Err(); err != nil {
+			if err := fn(path, nil, err); err != nil {
+				return err
+			}
+			continue
+		}
+		info := walker.Stat()
+		if err := fn(path, info, nil); err != nil {
+			if err == filepath.SkipDir {
+				if info.IsDir() {
+					walker.SkipDir()
+				} else {
+					skipSiblingsOf = filepath.Dir(path)
+				}
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// errSkipSiblings is returned internally by walkFTP when a non-directory
+// entry's callback returns filepath.SkipDir, telling the caller's entries
+// loop (the directory containing that entry) to stop iterating its
+// remaining siblings, matching filepath.Walk's contract.
+var errSkipSiblings = errors.New("sftp: skip remaining siblings")
 
-	info, err := c.sftpClient.Lstat(filePath)
+func walkFTP(ftpClient *goftp.Client, root string, fn filepath.WalkFunc) error {
+	info, err := ftpClient.Stat(root)
 	if err != nil {
-		return nil, fmt.Errorf("file stats: %w", err)
+		return fn(root, nil, err)
 	}
-
-	return info, nil
+	if err := fn(root, info, nil); err != nil {
+		if err == filepath.SkipDir {
+			if info.IsDir() {
+				return nil
+			}
+			return errSkipSiblings
+		}
+		return err
+	}
+	if !info.IsDir() {
+		return nil
+	}
+	entries, err := ftpClient.ReadDir(root)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := walkFTP(ftpClient, filepath.Join(root, entry.Name()), fn); err != nil {
+			if err == errSkipSiblings {
+				break
+			}
+			return err
+		}
+	}
+	return nil
 }
 
 // Close closes open connections.
 func (c *Client) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	if c.sftpClient != nil {
 		c.sftpClient.Close()
 	}
@@ -225,10 +642,113 @@ func (c *Client) Close() {
 	}
 }
 
+// authMethods builds the []ssh.AuthMethod to use for the connection from
+// whichever credentials were configured: SSH agent, private key(s)
+// (optionally encrypted), and password (with keyboard-interactive as a
+// fallback for servers that require it instead of plain password auth).
+func (c *Client) authMethods() ([]ssh.AuthMethod, error) {
+	var methods []ssh.AuthMethod
+
+	if c.config.UseAgent {
+		socket := os.Getenv("SSH_AUTH_SOCK")
+		if socket == "" {
+			return nil, errors.New("ssh: UseAgent is set but SSH_AUTH_SOCK is not")
+		}
+		conn, err := net.Dial("unix", socket)
+		if err != nil {
+			return nil, fmt.Errorf("ssh agent dial: %w", err)
+		}
+		methods = append(methods, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+	}
+
+	keys := c.config.PrivateKeys
+	if c.config.PrivateKey != "" {
+		keys = append([]string{c.config.PrivateKey}, keys...)
+	}
+	if len(keys) > 0 {
+		signers := make([]ssh.Signer, 0, len(keys))
+		for _, key := range keys {
+			signer, err := parsePrivateKey([]byte(key), c.config.PrivateKeyPassphrase)
+			if err != nil {
+				return nil, fmt.Errorf("ssh parse private key: %w", err)
+			}
+			signers = append(signers, signer)
+		}
+		methods = append(methods, ssh.PublicKeys(signers...))
+	}
+
+	if c.config.Password != "" {
+		methods = append(methods, ssh.Password(c.config.Password))
+		methods = append(methods, ssh.KeyboardInteractive(passwordKeyboardInteractive(c.config.Password)))
+	}
+
+	if len(methods) == 0 {
+		return nil, errors.New("ssh: no authentication method configured")
+	}
+
+	return methods, nil
+}
+
+// parsePrivateKey parses a PEM-encoded private key, decrypting it with
+// passphrase first if one is given.
+func parsePrivateKey(pemBytes []byte, passphrase string) (ssh.Signer, error) {
+	if passphrase != "" {
+		return ssh.ParsePrivateKeyWithPassphrase(pemBytes, []byte(passphrase))
+	}
+	return ssh.ParsePrivateKey(pemBytes)
+}
+
+// passwordKeyboardInteractive answers every keyboard-interactive prompt
+// with password, for servers configured to request it that way instead of
+// plain password auth.
+func passwordKeyboardInteractive(password string) ssh.KeyboardInteractiveChallenge {
+	return func(name, instruction string, questions []string, echos []bool) ([]string, error) {
+		answers := make([]string, len(questions))
+		for i := range answers {
+			answers[i] = password
+		}
+		return answers, nil
+	}
+}
+
+// hostKeyCallback builds the ssh.HostKeyCallback to use for the connection
+// from whichever verification option was configured. It rejects unknown
+// hosts unless InsecureSkipHostKeyVerify is explicitly set.
+func (c *Client) hostKeyCallback() (ssh.HostKeyCallback, error) {
+	switch {
+	case c.config.HostKeyCallback != nil:
+		return c.config.HostKeyCallback, nil
+	case c.config.KnownHostsFile != "":
+		callback, err := knownhosts.New(c.config.KnownHostsFile)
+		if err != nil {
+			return nil, fmt.Errorf("load known_hosts: %w", err)
+		}
+		return callback, nil
+	case len(c.config.HostKeyFingerprints) > 0:
+		allowed := make(map[string]bool, len(c.config.HostKeyFingerprints))
+		for _, fingerprint := range c.config.HostKeyFingerprints {
+			allowed[fingerprint] = true
+		}
+		return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			fingerprint := ssh.FingerprintSHA256(key)
+			if allowed[fingerprint] {
+				return nil
+			}
+			return fmt.Errorf("ssh: host key fingerprint %s for %s is not in the allowed list", fingerprint, hostname)
+		}, nil
+	case c.config.InsecureSkipHostKeyVerify:
+		return ssh.InsecureIgnoreHostKey(), nil
+	default:
+		return nil, errors.New("ssh: no host key verification configured; set KnownHostsFile, HostKeyFingerprints, HostKeyCallback, or InsecureSkipHostKeyVerify")
+	}
+}
+
 // connect initialises a new SSH and SFTP client only if they were not
 // initialised before at all and, they were initialised but the SSH
 // connection was lost for any reason.
 func (c *Client) connect() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
 	//Check if we should use a tls connection
 	if c.config.TLS {
@@ -236,11 +756,16 @@ func (c *Client) connect() error {
 			return nil
 		}
 		var err error
+		serverName := c.config.TLSServerName
+		if serverName == "" {
+			serverName = c.config.Server
+		}
 		// TLS client authentication
 		config := tls.Config{
-			InsecureSkipVerify: true,
-			ServerName:         c.config.Server,
+			InsecureSkipVerify: c.config.InsecureSkipHostKeyVerify,
+			ServerName:         serverName,
 			ClientAuth:         tls.RequestClientCert,
+			RootCAs:            c.config.TLSRootCAs,
 		}
 		cfg := goftp.Config{
 			User:             c.config.Username,
@@ -262,21 +787,20 @@ func (c *Client) connect() error {
 			return nil
 		}
 	}
-	auth := ssh.Password(c.config.Password)
-	if c.config.PrivateKey != "" {
-		signer, err := ssh.ParsePrivateKey([]byte(c.config.PrivateKey))
-		if err != nil {
-			return fmt.Errorf("ssh parse private key: %w", err)
-		}
-		auth = ssh.PublicKeys(signer)
+	authMethods, err := c.authMethods()
+	if err != nil {
+		return err
+	}
+
+	hostKeyCallback, err := c.hostKeyCallback()
+	if err != nil {
+		return err
 	}
 
 	cfg := &ssh.ClientConfig{
-		User: c.config.Username,
-		Auth: []ssh.AuthMethod{
-			auth,
-		},
-		HostKeyCallback: func(string, net.Addr, ssh.PublicKey) error { return nil },
+		User:            c.config.Username,
+		Auth:            authMethods,
+		HostKeyCallback: hostKeyCallback,
 		Timeout:         c.config.Timeout,
 		Config: ssh.Config{
 			KeyExchanges: c.config.KeyExchanges,
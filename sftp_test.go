@@ -0,0 +1,230 @@
+package sftp_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/partner4it/sftp/sftptest"
+)
+
+// testWriterAt is a fixed-size in-memory io.WriterAt, standing in for the
+// file callers would normally pass to DownloadConcurrent. Its size is
+// fixed upfront (like a pre-truncated file) so non-overlapping concurrent
+// WriteAt calls, as DownloadConcurrent makes, never race on a resize.
+type testWriterAt struct {
+	buf []byte
+}
+
+func (w *testWriterAt) WriteAt(p []byte, off int64) (int, error) {
+	copy(w.buf[off:], p)
+	return len(p), nil
+}
+
+func TestUploadDownloadRoundTrip(t *testing.T) {
+	srv := sftptest.NewServer(t)
+	client := srv.Client
+
+	if err := client.UploadFile("/greeting.txt", strings.NewReader("hello, world")); err != nil {
+		t.Fatalf("UploadFile: %v", err)
+	}
+
+	r, err := client.Download("/greeting.txt")
+	if err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	defer r.Close()
+
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("read download: %v", err)
+	}
+	if string(got) != "hello, world" {
+		t.Fatalf("got %q, want %q", got, "hello, world")
+	}
+
+	info, err := client.Info("/greeting.txt")
+	if err != nil {
+		t.Fatalf("Info: %v", err)
+	}
+	if info.Size() != int64(len("hello, world")) {
+		t.Fatalf("Info size = %d, want %d", info.Size(), len("hello, world"))
+	}
+
+	if err := client.Remove("/greeting.txt"); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if _, err := client.Info("/greeting.txt"); err == nil {
+		t.Fatal("Info on removed file: expected error, got nil")
+	}
+}
+
+func TestGlob(t *testing.T) {
+	srv := sftptest.NewServer(t)
+	client := srv.Client
+
+	if err := client.MkdirAll("/data"); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	for _, name := range []string{"/data/a.txt", "/data/b.txt", "/data/c.log"} {
+		if err := client.UploadFile(name, bytes.NewReader([]byte(name))); err != nil {
+			t.Fatalf("UploadFile(%s): %v", name, err)
+		}
+	}
+
+	matches, err := client.Glob("/data/*.txt")
+	if err != nil {
+		t.Fatalf("Glob: %v", err)
+	}
+	sort.Strings(matches)
+	want := []string{"/data/a.txt", "/data/b.txt"}
+	if len(matches) != len(want) {
+		t.Fatalf("Glob = %v, want %v", matches, want)
+	}
+	for i, m := range matches {
+		if m != want[i] {
+			t.Fatalf("Glob = %v, want %v", matches, want)
+		}
+	}
+}
+
+// TestWalkSkipDirOnFile is a regression test: returning filepath.SkipDir
+// from Walk's callback for a non-directory entry must not surface as a
+// hard error from Walk itself, and must skip the remaining siblings in
+// that file's containing directory, matching filepath.Walk's contract.
+func TestWalkSkipDirOnFile(t *testing.T) {
+	srv := sftptest.NewServer(t)
+	client := srv.Client
+
+	if err := client.MkdirAll("/dir"); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	if err := client.UploadFile("/dir/one.txt", bytes.NewReader([]byte("1"))); err != nil {
+		t.Fatalf("UploadFile: %v", err)
+	}
+	if err := client.UploadFile("/dir/two.txt", bytes.NewReader([]byte("2"))); err != nil {
+		t.Fatalf("UploadFile: %v", err)
+	}
+
+	var visited []string
+	err := client.Walk("/dir", func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		visited = append(visited, path)
+		if path == "/dir/one.txt" {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Walk returned %v, want nil (SkipDir on a file must be swallowed)", err)
+	}
+	want := []string{"/dir", "/dir/one.txt"}
+	if len(visited) != len(want) {
+		t.Fatalf("visited = %v, want %v (two.txt is a remaining sibling and must be skipped)", visited, want)
+	}
+	for i, path := range visited {
+		if path != want[i] {
+			t.Fatalf("visited = %v, want %v", visited, want)
+		}
+	}
+}
+
+func TestUploadDirDownloadDirRoundTrip(t *testing.T) {
+	srv := sftptest.NewServer(t)
+	client := srv.Client
+
+	local := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(local, "sub"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(local, "root.txt"), []byte("root"), 0o640); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(local, "sub", "nested.txt"), []byte("nested"), 0o640); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	// Chtimes/Chmod are best-effort here: the in-memory test server's
+	// Setstat only honors Size, so round-tripped mode/mtime aren't
+	// asserted, just that UploadDir/DownloadDir don't fail because of them.
+	if err := client.UploadDir(local, "/tree"); err != nil {
+		t.Fatalf("UploadDir: %v", err)
+	}
+
+	if _, err := client.Info("/tree/root.txt"); err != nil {
+		t.Fatalf("Info: %v", err)
+	}
+
+	downloaded := t.TempDir()
+	if err := client.DownloadDir("/tree", downloaded); err != nil {
+		t.Fatalf("DownloadDir: %v", err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(downloaded, "sub", "nested.txt"))
+	if err != nil {
+		t.Fatalf("read downloaded file: %v", err)
+	}
+	if string(got) != "nested" {
+		t.Fatalf("got %q, want %q", got, "nested")
+	}
+
+	root, err := os.ReadFile(filepath.Join(downloaded, "root.txt"))
+	if err != nil {
+		t.Fatalf("read downloaded root.txt: %v", err)
+	}
+	if string(root) != "root" {
+		t.Fatalf("got %q, want %q", root, "root")
+	}
+}
+
+// TestDownloadRange exercises the seeked-read path added for ranged/
+// concurrent downloads.
+func TestDownloadRange(t *testing.T) {
+	srv := sftptest.NewServer(t)
+	client := srv.Client
+
+	const content = "0123456789"
+	if err := client.UploadFile("/range.txt", bytes.NewReader([]byte(content))); err != nil {
+		t.Fatalf("UploadFile: %v", err)
+	}
+
+	r, err := client.DownloadRange("/range.txt", 3, 4)
+	if err != nil {
+		t.Fatalf("DownloadRange: %v", err)
+	}
+	defer r.Close()
+
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("read range: %v", err)
+	}
+	if string(got) != "3456" {
+		t.Fatalf("got %q, want %q", got, "3456")
+	}
+}
+
+// TestDownloadConcurrent exercises the chunked parallel download path,
+// including a chunk count that doesn't evenly divide the file size.
+func TestDownloadConcurrent(t *testing.T) {
+	srv := sftptest.NewServer(t)
+	client := srv.Client
+
+	const content = "the quick brown fox jumps over the lazy dog"
+	if err := client.UploadFile("/concurrent.txt", strings.NewReader(content)); err != nil {
+		t.Fatalf("UploadFile: %v", err)
+	}
+
+	w := &testWriterAt{buf: make([]byte, len(content))}
+	if err := client.DownloadConcurrent("/concurrent.txt", w, 5); err != nil {
+		t.Fatalf("DownloadConcurrent: %v", err)
+	}
+	if string(w.buf) != content {
+		t.Fatalf("got %q, want %q", w.buf, content)
+	}
+}
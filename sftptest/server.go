@@ -0,0 +1,138 @@
+// Package sftptest provides an in-process SFTP server for exercising
+// sftp.Client end-to-end without a real SSH daemon.
+package sftptest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+	"net"
+	"testing"
+
+	pkgsftp "github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+
+	sftpclient "github.com/partner4it/sftp"
+)
+
+// Server is an in-memory SSH+SFTP server started by NewServer.
+type Server struct {
+	// Addr is the address the server is listening on.
+	Addr string
+	// HostKey is the server's SSH host key.
+	HostKey ssh.PublicKey
+	// Client is already connected to the server.
+	Client *sftpclient.Client
+
+	listener net.Listener
+}
+
+// NewServer starts an in-memory SFTP server backed by handlers (defaulting
+// to pkgsftp.InMemHandler() when none is given) and returns it with a
+// *sftpclient.Client already connected. The server and client are torn
+// down via t.Cleanup.
+func NewServer(t *testing.T, handlers ...pkgsftp.Handlers) *Server {
+	t.Helper()
+
+	h := pkgsftp.InMemHandler()
+	if len(handlers) > 0 {
+		h = handlers[0]
+	}
+
+	hostKey, err := newHostKey()
+	if err != nil {
+		t.Fatalf("sftptest: generate host key: %v", err)
+	}
+
+	config := &ssh.ServerConfig{NoClientAuth: true}
+	config.AddHostKey(hostKey)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("sftptest: listen: %v", err)
+	}
+
+	go acceptOne(listener, config, h)
+
+	client, err := sftpclient.New(sftpclient.Config{
+		Username: "sftptest",
+		// The server has NoClientAuth set and ignores this; it only
+		// exists to satisfy Client's requirement that some credential be
+		// configured.
+		Password:                  "sftptest",
+		Server:                    listener.Addr().String(),
+		InsecureSkipHostKeyVerify: true,
+	})
+	if err != nil {
+		listener.Close()
+		t.Fatalf("sftptest: connect: %v", err)
+	}
+
+	t.Cleanup(func() {
+		client.Close()
+		listener.Close()
+	})
+
+	return &Server{
+		Addr:     listener.Addr().String(),
+		HostKey:  hostKey.PublicKey(),
+		Client:   client,
+		listener: listener,
+	}
+}
+
+// acceptOne accepts a single connection, performs the SSH handshake, and
+// serves the sftp subsystem on its session channel.
+func acceptOne(listener net.Listener, config *ssh.ServerConfig, handlers pkgsftp.Handlers) {
+	conn, err := listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	sshConn, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	defer sshConn.Close()
+	go ssh.DiscardRequests(reqs)
+
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "unknown channel type")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			continue
+		}
+		go serveSFTP(channel, requests, handlers)
+	}
+}
+
+// serveSFTP waits for the "sftp" subsystem request and then serves it,
+// discarding anything else (pty-req, env, ...).
+func serveSFTP(channel ssh.Channel, requests <-chan *ssh.Request, handlers pkgsftp.Handlers) {
+	defer channel.Close()
+	for req := range requests {
+		isSubsystem := req.Type == "subsystem" && string(req.Payload[4:]) == "sftp"
+		if req.WantReply {
+			req.Reply(isSubsystem, nil)
+		}
+		if !isSubsystem {
+			continue
+		}
+		server := pkgsftp.NewRequestServer(channel, handlers)
+		server.Serve()
+		server.Close()
+		return
+	}
+}
+
+func newHostKey() (ssh.Signer, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, fmt.Errorf("generate rsa key: %w", err)
+	}
+	return ssh.NewSignerFromKey(key)
+}